@@ -0,0 +1,75 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+// Cursor is a bidirectional, repositionable iterator over a KV range. Unlike
+// Iterator, which only ever moves forward from where it was created, a Cursor
+// can be seeked and reversed in place, which lets executors (index scans,
+// distinct-key aggregations, admin checks) reuse a single cursor instead of
+// creating a fresh forward-only Iterator for every access pattern.
+//
+// The existing Iter/IterReverse helpers on Retriever are separate from
+// Cursor: they do not create one.
+type Cursor interface {
+	Iterator
+	// Seek repositions the cursor on the first entry with key >= k. If no
+	// such entry exists, the cursor becomes invalid.
+	Seek(k Key) error
+	// SeekReverse repositions the cursor on the first entry with key < k and
+	// flips the cursor's direction so subsequent Next calls move to smaller
+	// keys. If k is nil, it behaves like Last.
+	SeekReverse(k Key) error
+	// Prev moves the cursor to the entry with the next smaller key.
+	Prev() error
+	// First repositions the cursor on the smallest key in range.
+	First() error
+	// Last repositions the cursor on the largest key in range.
+	Last() error
+}
+
+// CursorOptions configures a Cursor returned by CursorGetter.GetCursor.
+type CursorOptions struct {
+	// KeyOnly, when true, makes the cursor skip materializing values: Value()
+	// always returns nil. There is no tikv-backed Cursor, so nothing maps it
+	// to a request's KeyOnly flag.
+	KeyOnly bool
+	// Limit hints the maximum number of entries the caller will consume.
+	// MemCursorSource truncates the range to the first Limit entries from its
+	// lower bound; there is no tikv-backed Cursor that maps it to
+	// ScanRequest.limit.
+	Limit int
+}
+
+// CursorOption mutates CursorOptions; used as functional options to GetCursor.
+type CursorOption func(*CursorOptions)
+
+// WithKeyOnly returns a CursorOption that puts the cursor in key-only mode.
+func WithKeyOnly() CursorOption {
+	return func(o *CursorOptions) { o.KeyOnly = true }
+}
+
+// WithLimit returns a CursorOption that hints the cursor will be consumed for
+// at most limit entries.
+func WithLimit(limit int) CursorOption {
+	return func(o *CursorOptions) { o.Limit = limit }
+}
+
+// CursorGetter is implemented by types that can hand out a Cursor over a KV
+// range, such as MemCursorSource. MemBuffer and Snapshot do not implement it.
+type CursorGetter interface {
+	// GetCursor creates a Cursor over [lowerBound, upperBound). If
+	// upperBound is nil, the upper bound is unbounded. The Cursor must be
+	// Closed after use.
+	GetCursor(lowerBound, upperBound Key, opts ...CursorOption) (Cursor, error)
+}
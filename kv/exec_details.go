@@ -0,0 +1,150 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"time"
+)
+
+// ExecDetailsV2 holds per-request execution details of the kind TiKV reports
+// (timing, RocksDB-level scan statistics, write statistics), beyond the plain
+// wall-clock totals that ResultSubset/Response already expose. MemResultSubset
+// carries one and MemResponse aggregates them; the coprocessor and 2PC paths
+// do not fill it in, and EXPLAIN ANALYZE and the slow log do not read it.
+type ExecDetailsV2 struct {
+	// TimeDetail holds per-request timing.
+	TimeDetail TimeDetail
+	// ScanDetail holds per-request scan statistics.
+	ScanDetail ScanDetail
+	// WriteDetail holds per-request write statistics.
+	WriteDetail WriteDetail
+}
+
+// TimeDetail holds per-request timing as reported by TiKV.
+type TimeDetail struct {
+	// WaitWallTimeMs is the time spent waiting in TiKV's scheduler queue, in milliseconds.
+	WaitWallTimeMs int64
+	// ProcessWallTimeMs is the time TiKV spent actually processing the request, in milliseconds.
+	ProcessWallTimeMs int64
+	// KVReadWallTimeMs is the time spent reading from the underlying storage engine, in milliseconds.
+	KVReadWallTimeMs int64
+}
+
+// ScanDetail holds per-request RocksDB scan statistics as reported by TiKV.
+type ScanDetail struct {
+	// ProcessedVersions is the number of MVCC versions that contributed to the result.
+	ProcessedVersions uint64
+	// TotalVersions is the number of MVCC versions scanned, including ones skipped as stale or deleted.
+	TotalVersions uint64
+	// RocksdbBlockCacheHitCount is the number of RocksDB block cache hits.
+	RocksdbBlockCacheHitCount uint64
+	// RocksdbBlockReadCount is the number of RocksDB blocks read from disk.
+	RocksdbBlockReadCount uint64
+	// RocksdbBlockReadByte is the number of bytes read from disk by RocksDB.
+	RocksdbBlockReadByte uint64
+}
+
+// WriteDetail holds per-request write statistics as reported by TiKV.
+type WriteDetail struct {
+	// Mutations is the number of mutations carried by the request.
+	Mutations uint64
+	// PrewriteTime is the time spent in the prewrite phase.
+	PrewriteTime time.Duration
+	// CommitTime is the time spent in the commit phase.
+	CommitTime time.Duration
+}
+
+// Merge folds other into d, summing the numeric fields. It is used to
+// aggregate ExecDetailsV2 from multiple ResultSubsets into one Response-level
+// total.
+func (d *ExecDetailsV2) Merge(other *ExecDetailsV2) {
+	if other == nil {
+		return
+	}
+	d.TimeDetail.WaitWallTimeMs += other.TimeDetail.WaitWallTimeMs
+	d.TimeDetail.ProcessWallTimeMs += other.TimeDetail.ProcessWallTimeMs
+	d.TimeDetail.KVReadWallTimeMs += other.TimeDetail.KVReadWallTimeMs
+	d.ScanDetail.ProcessedVersions += other.ScanDetail.ProcessedVersions
+	d.ScanDetail.TotalVersions += other.ScanDetail.TotalVersions
+	d.ScanDetail.RocksdbBlockCacheHitCount += other.ScanDetail.RocksdbBlockCacheHitCount
+	d.ScanDetail.RocksdbBlockReadCount += other.ScanDetail.RocksdbBlockReadCount
+	d.ScanDetail.RocksdbBlockReadByte += other.ScanDetail.RocksdbBlockReadByte
+	d.WriteDetail.Mutations += other.WriteDetail.Mutations
+	d.WriteDetail.PrewriteTime += other.WriteDetail.PrewriteTime
+	d.WriteDetail.CommitTime += other.WriteDetail.CommitTime
+}
+
+// MemResultSubset is a ResultSubset backed by data already held in memory,
+// useful for tests and for backends (like an embedded engine) that never go
+// over a real RPC client.
+type MemResultSubset struct {
+	Data     []byte
+	StartKey Key
+	RespDur  time.Duration
+	Details  *ExecDetailsV2
+}
+
+// GetData implements ResultSubset.
+func (r *MemResultSubset) GetData() []byte { return r.Data }
+
+// GetStartKey implements ResultSubset.
+func (r *MemResultSubset) GetStartKey() Key { return r.StartKey }
+
+// MemSize implements ResultSubset.
+func (r *MemResultSubset) MemSize() int64 { return int64(len(r.Data)) }
+
+// RespTime implements ResultSubset.
+func (r *MemResultSubset) RespTime() time.Duration { return r.RespDur }
+
+// ExecDetails implements ResultSubset.
+func (r *MemResultSubset) ExecDetails() *ExecDetailsV2 { return r.Details }
+
+// MemResponse is a Response that replays a fixed, already-materialized slice
+// of ResultSubsets, aggregating their ExecDetailsV2 as Next is called. It is
+// intended for tests and for embedded backends that produce their full result
+// set up front instead of streaming it from a remote coprocessor.
+type MemResponse struct {
+	subsets   []ResultSubset
+	pos       int
+	collected *ExecDetailsV2
+}
+
+// NewMemResponse creates a MemResponse that returns subsets, in order, from
+// successive Next calls.
+func NewMemResponse(subsets ...ResultSubset) *MemResponse {
+	return &MemResponse{subsets: subsets}
+}
+
+// Next implements Response.
+func (r *MemResponse) Next(ctx context.Context) (ResultSubset, error) {
+	if r.pos >= len(r.subsets) {
+		return nil, nil
+	}
+	rs := r.subsets[r.pos]
+	r.pos++
+	if d := rs.ExecDetails(); d != nil {
+		if r.collected == nil {
+			r.collected = &ExecDetailsV2{}
+		}
+		r.collected.Merge(d)
+	}
+	return rs, nil
+}
+
+// Close implements Response.
+func (r *MemResponse) Close() error { return nil }
+
+// CollectedExecDetails implements Response.
+func (r *MemResponse) CollectedExecDetails() *ExecDetailsV2 { return r.collected }
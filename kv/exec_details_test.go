@@ -0,0 +1,76 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExecDetailsV2Merge(t *testing.T) {
+	d := &ExecDetailsV2{
+		TimeDetail: TimeDetail{WaitWallTimeMs: 1},
+		ScanDetail: ScanDetail{ProcessedVersions: 2},
+	}
+	d.Merge(&ExecDetailsV2{
+		TimeDetail: TimeDetail{WaitWallTimeMs: 10},
+		ScanDetail: ScanDetail{ProcessedVersions: 20},
+	})
+	if d.TimeDetail.WaitWallTimeMs != 11 || d.ScanDetail.ProcessedVersions != 22 {
+		t.Fatalf("Merge did not sum fields: %+v", d)
+	}
+	d.Merge(nil)
+	if d.TimeDetail.WaitWallTimeMs != 11 {
+		t.Fatalf("Merge(nil) should be a no-op, got %+v", d)
+	}
+}
+
+func TestMemResponseCollectsExecDetailsAcrossSubsets(t *testing.T) {
+	resp := NewMemResponse(
+		&MemResultSubset{Data: []byte("a"), Details: &ExecDetailsV2{ScanDetail: ScanDetail{ProcessedVersions: 3}}},
+		&MemResultSubset{Data: []byte("b"), Details: &ExecDetailsV2{ScanDetail: ScanDetail{ProcessedVersions: 4}}},
+		&MemResultSubset{Data: []byte("c")},
+	)
+
+	ctx := context.Background()
+	var gotData []string
+	for {
+		rs, err := resp.Next(ctx)
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if rs == nil {
+			break
+		}
+		gotData = append(gotData, string(rs.GetData()))
+	}
+	if want := []string{"a", "b", "c"}; !equalStrings(gotData, want) {
+		t.Fatalf("got %v, want %v", gotData, want)
+	}
+
+	collected := resp.CollectedExecDetails()
+	if collected == nil || collected.ScanDetail.ProcessedVersions != 7 {
+		t.Fatalf("CollectedExecDetails = %+v, want ProcessedVersions 7", collected)
+	}
+}
+
+func TestMemResponseWithNoExecDetailsCollectsNothing(t *testing.T) {
+	resp := NewMemResponse(&MemResultSubset{Data: []byte("a")})
+	if _, err := resp.Next(context.Background()); err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if got := resp.CollectedExecDetails(); got != nil {
+		t.Fatalf("CollectedExecDetails = %+v, want nil", got)
+	}
+}
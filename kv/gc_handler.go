@@ -0,0 +1,47 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import "context"
+
+// GCHandler exposes the GC safepoint subsystem of a Storage so external
+// coordinators (CDC, BR, analytics readers) can hold the safepoint back
+// without hacking into the GC worker themselves. Neither implementation in
+// this repository uses PD: MemGCHandler keeps the safepoint in memory, and the
+// badger backend stores it in its own engine under a key that mirrors the TiDB
+// GC worker's `/tidb/store/gcworker/saved_safe_point` layout.
+type GCHandler interface {
+	// UpdateServiceGCSafePoint registers serviceID's requested safe point with
+	// PD, and returns the minimum safe point across all registered services
+	// after the update. A ttl <= 0 removes serviceID's registration.
+	UpdateServiceGCSafePoint(ctx context.Context, serviceID string, ttl int64, safePoint uint64) (min uint64, err error)
+	// GetGCSafePoint returns the current GC safepoint. The result is cached
+	// for a short interval to avoid hot PD lookups.
+	GetGCSafePoint(ctx context.Context) (uint64, error)
+	// RegisterServiceGCSafePoint is a convenience wrapper around
+	// UpdateServiceGCSafePoint that discards the returned minimum safe point.
+	RegisterServiceGCSafePoint(ctx context.Context, serviceID string, ttl int64, safePoint uint64) error
+	// MinStartTS returns the smallest start timestamp among the transactions
+	// that have registered themselves with this handler, or the maximum
+	// uint64 if none have. Registered start timestamps do not hold the
+	// safepoint back: UpdateServiceGCSafePoint does not take them into
+	// account, and no commit path consults MinStartTS.
+	MinStartTS() uint64
+}
+
+// gcSafePointCacheInterval is the interval, in seconds, for which a
+// GCHandler's GetGCSafePoint may cache the safepoint. Nothing in this package
+// reads it: MemGCHandler does not cache, and the badger backend uses its own
+// gcSafePointCacheSeconds of the same value.
+const gcSafePointCacheInterval = 100 // seconds
@@ -58,6 +58,11 @@ const (
 	SampleStep
 	// CommitHook is a callback function called right after the transaction gets committed
 	CommitHook
+	// ResolveLocksOnRead selects read-through-lock mode on a snapshot. The
+	// badger snapshot ignores it. The resolve-and-retry loop itself is
+	// GetWithResolvedLocks, which records the start timestamps of resolved
+	// locks in a ResolvedLocks set so later reads do not resolve them again.
+	ResolveLocksOnRead
 )
 
 // Priority value for transaction priority.
@@ -411,6 +416,9 @@ type ResultSubset interface {
 	MemSize() int64
 	// RespTime returns the response time for the request.
 	RespTime() time.Duration
+	// ExecDetails returns the execution details TiKV reported for the request
+	// that produced this result subset, or nil if none were collected.
+	ExecDetails() *ExecDetailsV2
 }
 
 // Response represents the response returned from KV layer.
@@ -420,6 +428,9 @@ type Response interface {
 	Next(ctx context.Context) (resultSubset ResultSubset, err error)
 	// Close response.
 	Close() error
+	// CollectedExecDetails aggregates the ExecDetailsV2 of every ResultSubset
+	// returned so far by Next, or nil if none were collected.
+	CollectedExecDetails() *ExecDetailsV2
 }
 
 // Snapshot defines the interface for the snapshot fetched from KV store.
@@ -434,6 +445,25 @@ type Snapshot interface {
 	DelOption(opt Option)
 }
 
+// LockInfo describes a lock that was encountered while serving a read request
+// with ResolveLocksOnRead set, so the caller can resolve it out-of-band.
+type LockInfo struct {
+	PrimaryLock []byte
+	Key         []byte
+	LockVersion uint64
+	LockTTL     uint64
+	TxnSize     uint64
+}
+
+// ValueWithLocks pairs a value read under ResolveLocksOnRead with the locks that
+// were encountered while reading it. The caller resolves Locks through a lock
+// resolver and the resolved start timestamps are fed back into a
+// ResolvedLocks set so subsequent reads skip resolving them again.
+type ValueWithLocks struct {
+	Value []byte
+	Locks []LockInfo
+}
+
 // BatchGetter is the interface for BatchGet.
 type BatchGetter interface {
 	// BatchGet gets a batch of values.
@@ -447,6 +477,14 @@ type Driver interface {
 	Open(path string) (Storage, error)
 }
 
+// RawDriver is implemented by a Driver whose Storage can also advertise a
+// RawClient. Backends that only speak transactional KV do not implement it.
+type RawDriver interface {
+	// OpenRaw returns a new Storage with raw KV support, keyed under the given
+	// keyspace prefix so it cannot overlap keys used for transactional access.
+	OpenRaw(path string, rawKeyspacePrefix []byte) (Storage, error)
+}
+
 // Storage defines the interface for storage.
 // Isolation should be at least SI(SNAPSHOT ISOLATION)
 type Storage interface {
@@ -479,12 +517,20 @@ type Storage interface {
 	ShowStatus(ctx context.Context, key string) (interface{}, error)
 	// GetMemCache return memory mamager of the storage
 	GetMemCache() MemManager
+	// GetRawClient returns a RawClient for raw KV access bypassing the
+	// transactional/MVCC path. Backends that only support transactional access
+	// return a NotSupportedRawClient from this method.
+	GetRawClient() RawClient
+	// GetGCHandler returns the GCHandler used to coordinate the GC safepoint
+	// with external services.
+	GetGCHandler() GCHandler
 }
 
 // FnKeyCmp is the function for iterator the keys
 type FnKeyCmp func(key Key) bool
 
-// Iterator is the interface for a iterator on KV store.
+// Iterator is the interface for a iterator on KV store. It only ever moves
+// forward from where it was created; see Cursor for a repositionable variant.
 type Iterator interface {
 	Valid() bool
 	Key() Key
@@ -493,6 +539,14 @@ type Iterator interface {
 	Close()
 }
 
+// Compactor is implemented by a Storage whose engine supports manually
+// triggering compaction, such as an embedded LSM backend.
+type Compactor interface {
+	// Compact compacts the key range [startKey, endKey). A nil endKey compacts
+	// to the end of the keyspace.
+	Compact(startKey, endKey []byte) error
+}
+
 // SplittableStore is the kv store which supports split regions.
 type SplittableStore interface {
 	SplitRegions(ctx context.Context, splitKey [][]byte, scatter bool, tableID *int64) (regionID []uint64, err error)
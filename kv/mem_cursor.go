@@ -0,0 +1,166 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"bytes"
+	"sort"
+)
+
+// MemCursor is a Cursor over an in-memory, sorted slice of key/value pairs.
+// It backs MemCursorSource and is also useful on its own in tests that need
+// a Cursor without standing up a full Snapshot.
+type MemCursor struct {
+	entries []memCursorEntry
+	opts    CursorOptions
+
+	pos     int
+	valid   bool
+	reverse bool
+}
+
+type memCursorEntry struct {
+	key   Key
+	value []byte
+}
+
+func newMemCursor(entries []memCursorEntry, opts CursorOptions) *MemCursor {
+	return &MemCursor{entries: entries, opts: opts}
+}
+
+// Valid implements Iterator.
+func (c *MemCursor) Valid() bool { return c.valid }
+
+// Key implements Iterator.
+func (c *MemCursor) Key() Key {
+	if !c.valid {
+		return nil
+	}
+	return c.entries[c.pos].key
+}
+
+// Value implements Iterator.
+func (c *MemCursor) Value() []byte {
+	if !c.valid || c.opts.KeyOnly {
+		return nil
+	}
+	return c.entries[c.pos].value
+}
+
+// Next implements Iterator and Cursor: it advances in whichever direction the
+// cursor currently faces, set by the most recent Seek/SeekReverse/First/Last.
+func (c *MemCursor) Next() error {
+	if !c.valid {
+		return nil
+	}
+	if c.reverse {
+		return c.Prev()
+	}
+	c.pos++
+	c.valid = c.pos < len(c.entries)
+	return nil
+}
+
+// Prev implements Cursor.
+func (c *MemCursor) Prev() error {
+	if !c.valid {
+		return nil
+	}
+	c.pos--
+	c.valid = c.pos >= 0
+	return nil
+}
+
+// Seek implements Cursor.
+func (c *MemCursor) Seek(k Key) error {
+	c.reverse = false
+	c.pos = sort.Search(len(c.entries), func(i int) bool {
+		return bytes.Compare(c.entries[i].key, k) >= 0
+	})
+	c.valid = c.pos < len(c.entries)
+	return nil
+}
+
+// SeekReverse implements Cursor.
+func (c *MemCursor) SeekReverse(k Key) error {
+	c.reverse = true
+	if k == nil {
+		return c.Last()
+	}
+	c.pos = sort.Search(len(c.entries), func(i int) bool {
+		return bytes.Compare(c.entries[i].key, k) >= 0
+	}) - 1
+	c.valid = c.pos >= 0
+	return nil
+}
+
+// First implements Cursor.
+func (c *MemCursor) First() error {
+	c.reverse = false
+	c.pos = 0
+	c.valid = len(c.entries) > 0
+	return nil
+}
+
+// Last implements Cursor.
+func (c *MemCursor) Last() error {
+	c.reverse = true
+	c.pos = len(c.entries) - 1
+	c.valid = c.pos >= 0
+	return nil
+}
+
+// Close implements Iterator.
+func (c *MemCursor) Close() {}
+
+// MemCursorSource is an in-memory CursorGetter backed by a sorted snapshot of
+// key/value pairs. It is intended for tests and for embedded backends whose
+// data already lives in memory.
+type MemCursorSource struct {
+	entries []memCursorEntry
+}
+
+// NewMemCursorSource builds a MemCursorSource from kvs, sorting its entries
+// by key.
+func NewMemCursorSource(kvs map[string][]byte) *MemCursorSource {
+	entries := make([]memCursorEntry, 0, len(kvs))
+	for k, v := range kvs {
+		entries = append(entries, memCursorEntry{key: Key(k), value: v})
+	}
+	sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].key, entries[j].key) < 0 })
+	return &MemCursorSource{entries: entries}
+}
+
+// GetCursor implements CursorGetter.
+func (s *MemCursorSource) GetCursor(lowerBound, upperBound Key, opts ...CursorOption) (Cursor, error) {
+	var o CursorOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	lo := sort.Search(len(s.entries), func(i int) bool {
+		return lowerBound == nil || bytes.Compare(s.entries[i].key, lowerBound) >= 0
+	})
+	hi := len(s.entries)
+	if upperBound != nil {
+		hi = sort.Search(len(s.entries), func(i int) bool {
+			return bytes.Compare(s.entries[i].key, upperBound) >= 0
+		})
+	}
+	if o.Limit > 0 && hi-lo > o.Limit {
+		hi = lo + o.Limit
+	}
+
+	return newMemCursor(s.entries[lo:hi], o), nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import "testing"
+
+func newTestCursorSource() *MemCursorSource {
+	return NewMemCursorSource(map[string][]byte{
+		"a": []byte("1"),
+		"b": []byte("2"),
+		"c": []byte("3"),
+		"d": []byte("4"),
+	})
+}
+
+func collectForward(t *testing.T, c Cursor) []string {
+	t.Helper()
+	var got []string
+	for c.Valid() {
+		got = append(got, string(c.Key()))
+		if err := c.Next(); err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+	}
+	return got
+}
+
+func TestMemCursorSeekAndIterate(t *testing.T) {
+	src := newTestCursorSource()
+	c, err := src.GetCursor(Key("b"), nil)
+	if err != nil {
+		t.Fatalf("GetCursor: %v", err)
+	}
+	defer c.Close()
+	if err := c.First(); err != nil {
+		t.Fatalf("First: %v", err)
+	}
+	if got, want := collectForward(t, c), []string{"b", "c", "d"}; !equalStrings(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestMemCursorUpperBoundExclusive(t *testing.T) {
+	src := newTestCursorSource()
+	c, err := src.GetCursor(nil, Key("c"))
+	if err != nil {
+		t.Fatalf("GetCursor: %v", err)
+	}
+	defer c.Close()
+	if err := c.First(); err != nil {
+		t.Fatalf("First: %v", err)
+	}
+	if got, want := collectForward(t, c), []string{"a", "b"}; !equalStrings(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestMemCursorSeekReverseAndPrev(t *testing.T) {
+	src := newTestCursorSource()
+	c, err := src.GetCursor(nil, nil)
+	if err != nil {
+		t.Fatalf("GetCursor: %v", err)
+	}
+	defer c.Close()
+	if err := c.SeekReverse(Key("c")); err != nil {
+		t.Fatalf("SeekReverse: %v", err)
+	}
+
+	var got []string
+	for c.Valid() {
+		got = append(got, string(c.Key()))
+		if err := c.Next(); err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+	}
+	if want := []string{"b", "a"}; !equalStrings(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestMemCursorKeyOnly(t *testing.T) {
+	src := newTestCursorSource()
+	c, err := src.GetCursor(nil, nil, WithKeyOnly())
+	if err != nil {
+		t.Fatalf("GetCursor: %v", err)
+	}
+	defer c.Close()
+	if err := c.First(); err != nil {
+		t.Fatalf("First: %v", err)
+	}
+	if v := c.Value(); v != nil {
+		t.Fatalf("Value() with KeyOnly = %q, want nil", v)
+	}
+}
+
+func TestMemCursorLimit(t *testing.T) {
+	src := newTestCursorSource()
+	c, err := src.GetCursor(nil, nil, WithLimit(2))
+	if err != nil {
+		t.Fatalf("GetCursor: %v", err)
+	}
+	defer c.Close()
+	if err := c.First(); err != nil {
+		t.Fatalf("First: %v", err)
+	}
+	if got, want := collectForward(t, c), []string{"a", "b"}; !equalStrings(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
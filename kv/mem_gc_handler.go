@@ -0,0 +1,121 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemGCHandler is an in-memory GCHandler, intended for tests and for
+// embedded storage backends that have no PD to persist the safepoint into.
+// It is safe for concurrent use.
+type MemGCHandler struct {
+	mu           sync.Mutex
+	safePoint    uint64
+	services     map[string]memServiceSafePoint
+	registeredTS map[uint64]struct{}
+}
+
+type memServiceSafePoint struct {
+	safePoint uint64
+	expiresAt time.Time
+}
+
+// memMaxTS is the largest representable uint64 timestamp, used as the
+// identity element when folding a set of timestamps down to a minimum.
+const memMaxTS = uint64(1<<64 - 1)
+
+// NewMemGCHandler creates an empty MemGCHandler.
+func NewMemGCHandler() *MemGCHandler {
+	return &MemGCHandler{
+		services:     make(map[string]memServiceSafePoint),
+		registeredTS: make(map[uint64]struct{}),
+	}
+}
+
+// UpdateServiceGCSafePoint implements GCHandler.
+func (h *MemGCHandler) UpdateServiceGCSafePoint(ctx context.Context, serviceID string, ttl int64, safePoint uint64) (uint64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if ttl <= 0 {
+		delete(h.services, serviceID)
+	} else {
+		h.services[serviceID] = memServiceSafePoint{
+			safePoint: safePoint,
+			expiresAt: time.Now().Add(time.Duration(ttl) * time.Second),
+		}
+	}
+
+	min := memMaxTS
+	if ttl > 0 && safePoint < min {
+		min = safePoint
+	}
+	now := time.Now()
+	for id, sp := range h.services {
+		if now.After(sp.expiresAt) {
+			delete(h.services, id)
+			continue
+		}
+		if sp.safePoint < min {
+			min = sp.safePoint
+		}
+	}
+	h.safePoint = min
+	return min, nil
+}
+
+// GetGCSafePoint implements GCHandler. Unlike a PD-backed handler there is no
+// remote round trip to cache, so the value is simply returned directly.
+func (h *MemGCHandler) GetGCSafePoint(ctx context.Context) (uint64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.safePoint, nil
+}
+
+// RegisterServiceGCSafePoint implements GCHandler.
+func (h *MemGCHandler) RegisterServiceGCSafePoint(ctx context.Context, serviceID string, ttl int64, safePoint uint64) error {
+	_, err := h.UpdateServiceGCSafePoint(ctx, serviceID, ttl, safePoint)
+	return err
+}
+
+// MinStartTS implements GCHandler.
+func (h *MemGCHandler) MinStartTS() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	min := memMaxTS
+	for ts := range h.registeredTS {
+		if ts < min {
+			min = ts
+		}
+	}
+	return min
+}
+
+// RegisterStartTS records startTS so MinStartTS reflects it until
+// UnregisterStartTS is called.
+func (h *MemGCHandler) RegisterStartTS(startTS uint64) {
+	h.mu.Lock()
+	h.registeredTS[startTS] = struct{}{}
+	h.mu.Unlock()
+}
+
+// UnregisterStartTS removes a previously registered startTS.
+func (h *MemGCHandler) UnregisterStartTS(startTS uint64) {
+	h.mu.Lock()
+	delete(h.registeredTS, startTS)
+	h.mu.Unlock()
+}
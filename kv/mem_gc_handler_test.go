@@ -0,0 +1,63 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemGCHandlerUpdateServiceGCSafePointTakesMinimum(t *testing.T) {
+	h := NewMemGCHandler()
+	ctx := context.Background()
+
+	min, err := h.UpdateServiceGCSafePoint(ctx, "svc-a", 60, 100)
+	if err != nil || min != 100 {
+		t.Fatalf("UpdateServiceGCSafePoint = (%d, %v), want (100, nil)", min, err)
+	}
+	min, err = h.UpdateServiceGCSafePoint(ctx, "svc-b", 60, 50)
+	if err != nil || min != 50 {
+		t.Fatalf("UpdateServiceGCSafePoint = (%d, %v), want (50, nil)", min, err)
+	}
+
+	sp, err := h.GetGCSafePoint(ctx)
+	if err != nil || sp != 50 {
+		t.Fatalf("GetGCSafePoint = (%d, %v), want (50, nil)", sp, err)
+	}
+
+	// Removing svc-b's registration should let the safe point advance again.
+	min, err = h.UpdateServiceGCSafePoint(ctx, "svc-b", 0, 0)
+	if err != nil || min != 100 {
+		t.Fatalf("UpdateServiceGCSafePoint after removal = (%d, %v), want (100, nil)", min, err)
+	}
+}
+
+func TestMemGCHandlerMinStartTS(t *testing.T) {
+	h := NewMemGCHandler()
+	if got := h.MinStartTS(); got != 1<<64-1 {
+		t.Fatalf("MinStartTS with nothing registered = %d, want max uint64", got)
+	}
+
+	h.RegisterStartTS(20)
+	h.RegisterStartTS(10)
+	h.RegisterStartTS(30)
+	if got := h.MinStartTS(); got != 10 {
+		t.Fatalf("MinStartTS = %d, want 10", got)
+	}
+
+	h.UnregisterStartTS(10)
+	if got := h.MinStartTS(); got != 20 {
+		t.Fatalf("MinStartTS after unregister = %d, want 20", got)
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"fmt"
+)
+
+// errRawNotSupported is returned by NotSupportedRawClient for every method.
+var errRawNotSupported = fmt.Errorf("kv: raw KV access is not supported by this storage")
+
+// NotSupportedRawClient is a RawClient that rejects every call. Storage
+// implementations that only speak transactional KV return it from
+// Storage.GetRawClient instead of implementing a real raw path.
+type NotSupportedRawClient struct{}
+
+// Put implements RawClient.
+func (NotSupportedRawClient) Put(ctx context.Context, key, value []byte) error {
+	return errRawNotSupported
+}
+
+// PutWithTTL implements RawClient.
+func (NotSupportedRawClient) PutWithTTL(ctx context.Context, key, value []byte, ttl uint64) error {
+	return errRawNotSupported
+}
+
+// Get implements RawClient.
+func (NotSupportedRawClient) Get(ctx context.Context, key []byte) ([]byte, error) {
+	return nil, errRawNotSupported
+}
+
+// Delete implements RawClient.
+func (NotSupportedRawClient) Delete(ctx context.Context, key []byte) error { return errRawNotSupported }
+
+// BatchPut implements RawClient.
+func (NotSupportedRawClient) BatchPut(ctx context.Context, keys, values [][]byte) error {
+	return errRawNotSupported
+}
+
+// BatchGet implements RawClient.
+func (NotSupportedRawClient) BatchGet(ctx context.Context, keys [][]byte) (map[string][]byte, error) {
+	return nil, errRawNotSupported
+}
+
+// BatchDelete implements RawClient.
+func (NotSupportedRawClient) BatchDelete(ctx context.Context, keys [][]byte) error {
+	return errRawNotSupported
+}
+
+// Scan implements RawClient.
+func (NotSupportedRawClient) Scan(ctx context.Context, startKey, endKey []byte, limit int) ([][]byte, [][]byte, error) {
+	return nil, nil, errRawNotSupported
+}
+
+// ReverseScan implements RawClient.
+func (NotSupportedRawClient) ReverseScan(ctx context.Context, startKey, endKey []byte, limit int) ([][]byte, [][]byte, error) {
+	return nil, nil, errRawNotSupported
+}
+
+// DeleteRange implements RawClient.
+func (NotSupportedRawClient) DeleteRange(ctx context.Context, startKey, endKey []byte) error {
+	return errRawNotSupported
+}
+
+// CompareAndSwap implements RawClient.
+func (NotSupportedRawClient) CompareAndSwap(ctx context.Context, key, expectedValue, newValue []byte) ([]byte, bool, error) {
+	return nil, false, errRawNotSupported
+}
+
+// Close implements RawClient.
+func (NotSupportedRawClient) Close() error { return nil }
+
+// RawStorage defines the interface for a schema-less, non-transactional
+// key-value store. It bypasses the 2PC/MVCC path used by Transaction, which
+// makes it cheaper than Transaction for side stores (metadata, queues, caches)
+// that do not need MVCC semantics. The badger backend implements it directly
+// on its embedded engine; there is no TiKV-backed implementation.
+//
+// Mixing raw and transactional access on overlapping key ranges is unsafe:
+// raw writes are not versioned and are invisible to the GC and lock-resolution
+// machinery that transactional reads rely on. RawStorage instances must be
+// constructed with a keyspace prefix that does not overlap any keyspace used
+// for transactional access; see NewRawKeyspaceGuard.
+type RawStorage interface {
+	// Put writes a key-value pair.
+	Put(ctx context.Context, key, value []byte) error
+	// PutWithTTL writes a key-value pair that expires after ttl seconds. A ttl
+	// of 0 means the key never expires.
+	PutWithTTL(ctx context.Context, key, value []byte, ttl uint64) error
+	// Get reads the value for key. It returns ErrNotExist if the key does not exist.
+	Get(ctx context.Context, key []byte) ([]byte, error)
+	// Delete removes the entry for key, if any.
+	Delete(ctx context.Context, key []byte) error
+	// BatchPut writes multiple key-value pairs.
+	BatchPut(ctx context.Context, keys, values [][]byte) error
+	// BatchGet reads the values for keys. Keys that do not exist are omitted
+	// from the result map.
+	BatchGet(ctx context.Context, keys [][]byte) (map[string][]byte, error)
+	// BatchDelete removes the entries for keys.
+	BatchDelete(ctx context.Context, keys [][]byte) error
+	// Scan returns up to limit key-value pairs in [startKey, endKey) in ascending order.
+	Scan(ctx context.Context, startKey, endKey []byte, limit int) (keys [][]byte, values [][]byte, err error)
+	// ReverseScan returns up to limit key-value pairs in [startKey, endKey) in descending order.
+	ReverseScan(ctx context.Context, startKey, endKey []byte, limit int) (keys [][]byte, values [][]byte, err error)
+	// DeleteRange removes all keys in [startKey, endKey).
+	DeleteRange(ctx context.Context, startKey, endKey []byte) error
+	// CompareAndSwap atomically sets key to newValue if its current value
+	// equals expectedValue, and returns the previous value together with
+	// whether the swap happened.
+	CompareAndSwap(ctx context.Context, key, expectedValue, newValue []byte) (previousValue []byte, swapped bool, err error)
+}
+
+// RawClient serves raw requests without going through the 2PC/MVCC machinery
+// used by Transaction/Snapshot.
+type RawClient interface {
+	RawStorage
+	// Close releases the resources held by the client.
+	Close() error
+}
+
+// rawKeyspaceGuard wraps a RawClient and rejects any key outside of the
+// configured keyspace prefix, so a RawStorage cannot accidentally reach into a
+// keyspace used for transactional access.
+type rawKeyspaceGuard struct {
+	RawClient
+	prefix []byte
+}
+
+// NewRawKeyspaceGuard wraps client so every key it is asked to operate on must
+// be prefixed with prefix. This is used to enforce separation between raw and
+// transactional keyspaces on the same cluster.
+func NewRawKeyspaceGuard(client RawClient, prefix []byte) RawClient {
+	return &rawKeyspaceGuard{RawClient: client, prefix: prefix}
+}
+
+func (g *rawKeyspaceGuard) checkKey(key []byte) error {
+	if len(key) < len(g.prefix) || string(key[:len(g.prefix)]) != string(g.prefix) {
+		return fmt.Errorf("kv: raw key %q is outside of keyspace prefix %q", key, g.prefix)
+	}
+	return nil
+}
+
+func (g *rawKeyspaceGuard) checkKeys(keys [][]byte) error {
+	for _, k := range keys {
+		if err := g.checkKey(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *rawKeyspaceGuard) Put(ctx context.Context, key, value []byte) error {
+	if err := g.checkKey(key); err != nil {
+		return err
+	}
+	return g.RawClient.Put(ctx, key, value)
+}
+
+func (g *rawKeyspaceGuard) PutWithTTL(ctx context.Context, key, value []byte, ttl uint64) error {
+	if err := g.checkKey(key); err != nil {
+		return err
+	}
+	return g.RawClient.PutWithTTL(ctx, key, value, ttl)
+}
+
+func (g *rawKeyspaceGuard) Get(ctx context.Context, key []byte) ([]byte, error) {
+	if err := g.checkKey(key); err != nil {
+		return nil, err
+	}
+	return g.RawClient.Get(ctx, key)
+}
+
+func (g *rawKeyspaceGuard) Delete(ctx context.Context, key []byte) error {
+	if err := g.checkKey(key); err != nil {
+		return err
+	}
+	return g.RawClient.Delete(ctx, key)
+}
+
+func (g *rawKeyspaceGuard) BatchPut(ctx context.Context, keys, values [][]byte) error {
+	if err := g.checkKeys(keys); err != nil {
+		return err
+	}
+	return g.RawClient.BatchPut(ctx, keys, values)
+}
+
+func (g *rawKeyspaceGuard) BatchGet(ctx context.Context, keys [][]byte) (map[string][]byte, error) {
+	if err := g.checkKeys(keys); err != nil {
+		return nil, err
+	}
+	return g.RawClient.BatchGet(ctx, keys)
+}
+
+func (g *rawKeyspaceGuard) BatchDelete(ctx context.Context, keys [][]byte) error {
+	if err := g.checkKeys(keys); err != nil {
+		return err
+	}
+	return g.RawClient.BatchDelete(ctx, keys)
+}
+
+func (g *rawKeyspaceGuard) Scan(ctx context.Context, startKey, endKey []byte, limit int) ([][]byte, [][]byte, error) {
+	if err := g.checkKeys([][]byte{startKey, endKey}); err != nil {
+		return nil, nil, err
+	}
+	return g.RawClient.Scan(ctx, startKey, endKey, limit)
+}
+
+func (g *rawKeyspaceGuard) ReverseScan(ctx context.Context, startKey, endKey []byte, limit int) ([][]byte, [][]byte, error) {
+	if err := g.checkKeys([][]byte{startKey, endKey}); err != nil {
+		return nil, nil, err
+	}
+	return g.RawClient.ReverseScan(ctx, startKey, endKey, limit)
+}
+
+func (g *rawKeyspaceGuard) DeleteRange(ctx context.Context, startKey, endKey []byte) error {
+	if err := g.checkKeys([][]byte{startKey, endKey}); err != nil {
+		return err
+	}
+	return g.RawClient.DeleteRange(ctx, startKey, endKey)
+}
+
+func (g *rawKeyspaceGuard) CompareAndSwap(ctx context.Context, key, expectedValue, newValue []byte) ([]byte, bool, error) {
+	if err := g.checkKey(key); err != nil {
+		return nil, false, err
+	}
+	return g.RawClient.CompareAndSwap(ctx, key, expectedValue, newValue)
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"testing"
+)
+
+// memRawClient is a minimal in-memory RawClient used to verify that
+// rawKeyspaceGuard only ever forwards calls that pass its prefix check.
+type memRawClient struct {
+	NotSupportedRawClient
+	data map[string][]byte
+}
+
+func newMemRawClient() *memRawClient {
+	return &memRawClient{data: make(map[string][]byte)}
+}
+
+func (c *memRawClient) Put(ctx context.Context, key, value []byte) error {
+	c.data[string(key)] = value
+	return nil
+}
+
+func (c *memRawClient) Scan(ctx context.Context, startKey, endKey []byte, limit int) ([][]byte, [][]byte, error) {
+	return nil, nil, nil
+}
+
+func (c *memRawClient) DeleteRange(ctx context.Context, startKey, endKey []byte) error {
+	return nil
+}
+
+func TestRawKeyspaceGuardAcceptsInPrefixKeys(t *testing.T) {
+	inner := newMemRawClient()
+	g := NewRawKeyspaceGuard(inner, []byte("p/"))
+	ctx := context.Background()
+
+	if err := g.Put(ctx, []byte("p/a"), []byte("v")); err != nil {
+		t.Fatalf("Put with in-prefix key: %v", err)
+	}
+	if inner.data["p/a"] == nil {
+		t.Fatalf("Put did not reach the wrapped client")
+	}
+
+	if _, _, err := g.Scan(ctx, []byte("p/a"), []byte("p/z"), 10); err != nil {
+		t.Fatalf("Scan with in-prefix start/end: %v", err)
+	}
+
+	if err := g.DeleteRange(ctx, []byte("p/a"), []byte("p/z")); err != nil {
+		t.Fatalf("DeleteRange with in-prefix start/end: %v", err)
+	}
+}
+
+func TestRawKeyspaceGuardRejectsOutOfPrefixKeys(t *testing.T) {
+	inner := newMemRawClient()
+	g := NewRawKeyspaceGuard(inner, []byte("p/"))
+	ctx := context.Background()
+
+	if err := g.Put(ctx, []byte("q/a"), []byte("v")); err == nil {
+		t.Fatalf("Put with out-of-prefix key should have been rejected")
+	}
+	if inner.data["q/a"] != nil {
+		t.Fatalf("Put should not have reached the wrapped client")
+	}
+
+	if _, _, err := g.Scan(ctx, []byte("p/a"), []byte("q/z"), 10); err == nil {
+		t.Fatalf("Scan with out-of-prefix endKey should have been rejected")
+	}
+	if _, _, err := g.Scan(ctx, []byte("q/a"), []byte("p/z"), 10); err == nil {
+		t.Fatalf("Scan with out-of-prefix startKey should have been rejected")
+	}
+
+	if err := g.DeleteRange(ctx, []byte("p/a"), []byte("q/z")); err == nil {
+		t.Fatalf("DeleteRange with out-of-prefix endKey should have been rejected")
+	}
+}
+
+func TestRawKeyspaceGuardRejectsNilEndKeyAsUnbounded(t *testing.T) {
+	inner := newMemRawClient()
+	g := NewRawKeyspaceGuard(inner, []byte("p/"))
+	ctx := context.Background()
+
+	// A nil endKey conventionally means "unbounded", which would let a scan
+	// or range-delete reach past the configured prefix into another
+	// keyspace; the guard must reject it rather than let it through.
+	if _, _, err := g.Scan(ctx, []byte("p/a"), nil, 10); err == nil {
+		t.Fatalf("Scan with nil (unbounded) endKey should have been rejected")
+	}
+	if err := g.DeleteRange(ctx, []byte("p/a"), nil); err == nil {
+		t.Fatalf("DeleteRange with nil (unbounded) endKey should have been rejected")
+	}
+}
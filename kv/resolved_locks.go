@@ -0,0 +1,108 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ResolvedLocks is a set of start timestamps of locks that have already been
+// resolved in read-through-lock mode. GetWithResolvedLocks
+// consults it before resolving a lock so a transaction that is re-encountered
+// across several reads (e.g. during BatchGet or a long scan) is only resolved
+// once, instead of round-tripping to the lock resolver every time.
+type ResolvedLocks struct {
+	mu    sync.RWMutex
+	locks map[uint64]struct{}
+}
+
+// NewResolvedLocks creates an empty ResolvedLocks set.
+func NewResolvedLocks() *ResolvedLocks {
+	return &ResolvedLocks{locks: make(map[uint64]struct{})}
+}
+
+// Has returns whether the lock with the given start timestamp has already
+// been resolved.
+func (r *ResolvedLocks) Has(startTS uint64) bool {
+	r.mu.RLock()
+	_, ok := r.locks[startTS]
+	r.mu.RUnlock()
+	return ok
+}
+
+// Put records that the lock with the given start timestamp has been resolved.
+func (r *ResolvedLocks) Put(startTS uint64) {
+	r.mu.Lock()
+	r.locks[startTS] = struct{}{}
+	r.mu.Unlock()
+}
+
+// Filter removes the start timestamps that are already resolved from locks,
+// returning only the ones that still need resolving.
+func (r *ResolvedLocks) Filter(locks []LockInfo) []LockInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	unresolved := locks[:0:0]
+	for _, l := range locks {
+		if _, ok := r.locks[l.LockVersion]; !ok {
+			unresolved = append(unresolved, l)
+		}
+	}
+	return unresolved
+}
+
+// LockedError is the error a read passed to GetWithResolvedLocks returns when
+// it encounters one or more locks it cannot resolve on its own.
+// GetWithResolvedLocks resolves Locks through its resolve callback and
+// retries the read.
+type LockedError struct {
+	Locks []LockInfo
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("kv: get encountered %d unresolved lock(s)", len(e.Locks))
+}
+
+// GetWithResolvedLocks runs get and, each time it fails with a *LockedError,
+// resolves the still-unresolved locks via resolve and retries. resolved locks
+// are recorded in seen so a lock already resolved earlier in the same
+// snapshot's lifetime (e.g. re-encountered on a later BatchGet) is not
+// resolved twice. It gives up and returns the last LockedError if resolve
+// does not clear the full set of locks after maxAttempts tries.
+func GetWithResolvedLocks(ctx context.Context, seen *ResolvedLocks, maxAttempts int, get func(ctx context.Context) ([]byte, error), resolve func(ctx context.Context, locks []LockInfo) error) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		val, err := get(ctx)
+		lockedErr, ok := err.(*LockedError)
+		if !ok {
+			return val, err
+		}
+		if attempt >= maxAttempts {
+			return nil, err
+		}
+		unresolved := seen.Filter(lockedErr.Locks)
+		if len(unresolved) == 0 {
+			// Every lock was already resolved earlier yet the read is still
+			// seeing them; nothing more we can do without re-resolving.
+			return nil, err
+		}
+		if err := resolve(ctx, unresolved); err != nil {
+			return nil, err
+		}
+		for _, l := range unresolved {
+			seen.Put(l.LockVersion)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"testing"
+)
+
+func TestResolvedLocksFilter(t *testing.T) {
+	r := NewResolvedLocks()
+	r.Put(1)
+	locks := []LockInfo{{LockVersion: 1}, {LockVersion: 2}}
+	unresolved := r.Filter(locks)
+	if len(unresolved) != 1 || unresolved[0].LockVersion != 2 {
+		t.Fatalf("Filter = %+v, want only LockVersion 2", unresolved)
+	}
+	if !r.Has(1) || r.Has(2) {
+		t.Fatalf("Has returned wrong state after Put(1)")
+	}
+}
+
+func TestGetWithResolvedLocksRetriesUntilClear(t *testing.T) {
+	seen := NewResolvedLocks()
+	calls := 0
+	get := func(ctx context.Context) ([]byte, error) {
+		calls++
+		if calls < 3 {
+			return nil, &LockedError{Locks: []LockInfo{{LockVersion: uint64(calls)}}}
+		}
+		return []byte("v"), nil
+	}
+	var resolvedVersions []uint64
+	resolve := func(ctx context.Context, locks []LockInfo) error {
+		for _, l := range locks {
+			resolvedVersions = append(resolvedVersions, l.LockVersion)
+		}
+		return nil
+	}
+
+	val, err := GetWithResolvedLocks(context.Background(), seen, 5, get, resolve)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(val) != "v" {
+		t.Fatalf("got value %q, want %q", val, "v")
+	}
+	if len(resolvedVersions) != 2 || resolvedVersions[0] != 1 || resolvedVersions[1] != 2 {
+		t.Fatalf("resolved versions = %v, want [1 2]", resolvedVersions)
+	}
+	if !seen.Has(1) || !seen.Has(2) {
+		t.Fatalf("seen should record every resolved lock version")
+	}
+}
+
+func TestGetWithResolvedLocksStopsWhenAlreadySeenLockPersists(t *testing.T) {
+	seen := NewResolvedLocks()
+	seen.Put(7)
+	wantErr := &LockedError{Locks: []LockInfo{{LockVersion: 7}}}
+	get := func(ctx context.Context) ([]byte, error) {
+		return nil, wantErr
+	}
+	resolveCalls := 0
+	resolve := func(ctx context.Context, locks []LockInfo) error {
+		resolveCalls++
+		return nil
+	}
+
+	_, err := GetWithResolvedLocks(context.Background(), seen, 5, get, resolve)
+	if err != wantErr {
+		t.Fatalf("got error %v, want %v", err, wantErr)
+	}
+	if resolveCalls != 0 {
+		t.Fatalf("resolve should not be called when every lock is already marked resolved")
+	}
+}
+
+func TestGetWithResolvedLocksGivesUpAfterMaxAttempts(t *testing.T) {
+	seen := NewResolvedLocks()
+	calls := 0
+	get := func(ctx context.Context) ([]byte, error) {
+		calls++
+		return nil, &LockedError{Locks: []LockInfo{{LockVersion: uint64(calls)}}}
+	}
+	resolve := func(ctx context.Context, locks []LockInfo) error { return nil }
+
+	_, err := GetWithResolvedLocks(context.Background(), seen, 2, get, resolve)
+	if err == nil {
+		t.Fatalf("expected an error once maxAttempts is exhausted")
+	}
+	if calls != 3 {
+		t.Fatalf("got %d calls to get, want 3 (1 initial + 2 retries)", calls)
+	}
+}
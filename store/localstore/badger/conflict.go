@@ -0,0 +1,60 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package badger
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger/v2"
+)
+
+// writeConflictError is returned by txn.Commit when first-committer-wins
+// detects that key was committed by another transaction after this
+// transaction's startTS.
+type writeConflictError struct {
+	key            []byte
+	startTS        uint64
+	conflictCommit uint64
+}
+
+func newWriteConflictError(key []byte, startTS, conflictCommitTS uint64) error {
+	return &writeConflictError{key: key, startTS: startTS, conflictCommit: conflictCommitTS}
+}
+
+func (e *writeConflictError) Error() string {
+	return fmt.Sprintf("badger: write conflict, key %q was committed at ts %d, after this transaction's start ts %d",
+		e.key, e.conflictCommit, e.startTS)
+}
+
+// latestCommitTS returns the commit timestamp of the newest version of
+// userKey in the engine, regardless of any snapshot's readTS, plus whether
+// any version of the key exists at all. It is used by txn.checkForConflicts
+// to detect first-committer-wins violations.
+func (s *storage) latestCommitTS(userKey []byte) (ts uint64, found bool, err error) {
+	err = s.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		it.Seek(mvccEncode(userKey, maxTS))
+		if !it.Valid() {
+			return nil
+		}
+		uk, t, ok := mvccDecode(it.Item().KeyCopy(nil))
+		if !ok || string(uk) != string(userKey) {
+			return nil
+		}
+		ts, found = t, true
+		return nil
+	})
+	return ts, found, err
+}
@@ -0,0 +1,29 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package badger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteConflictErrorMentionsKeyAndTimestamps(t *testing.T) {
+	err := newWriteConflictError([]byte("k1"), 10, 20)
+	msg := err.Error()
+	for _, want := range []string{"k1", "10", "20"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("writeConflictError message %q missing %q", msg, want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package badger
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+)
+
+// gcSafePointKey is the engine key badger's GCHandler persists the safepoint
+// under. It intentionally mirrors the layout of the tikv backend's PD key
+// (`/tidb/store/gcworker/saved_safe_point`) so the two GCHandlers behave the
+// same way from a caller's point of view.
+const gcSafePointKey = "\xff\xff/tidb/store/gcworker/saved_safe_point"
+
+// gcSafePointCacheSeconds mirrors the kv package's 100s
+// gcSafePointCacheInterval for GetGCSafePoint.
+const gcSafePointCacheSeconds = 100
+
+// gcHandler implements kv.GCHandler for the badger backend. It persists the
+// safe point in the same engine the rest of the data lives in (rather than
+// PD, which an embedded single-node deployment does not have), and drives the
+// engine's value-log GC off of it.
+type gcHandler struct {
+	store *storage
+
+	mu           sync.Mutex
+	services     map[string]serviceSafePoint
+	cachedSP     uint64
+	cachedAt     time.Time
+	registeredTS map[uint64]struct{}
+}
+
+type serviceSafePoint struct {
+	safePoint uint64
+	expiresAt time.Time
+}
+
+func newGCHandler(store *storage) *gcHandler {
+	return &gcHandler{
+		store:        store,
+		services:     make(map[string]serviceSafePoint),
+		registeredTS: make(map[uint64]struct{}),
+	}
+}
+
+// UpdateServiceGCSafePoint implements kv.GCHandler.
+func (h *gcHandler) UpdateServiceGCSafePoint(ctx context.Context, serviceID string, ttl int64, safePoint uint64) (uint64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if ttl <= 0 {
+		delete(h.services, serviceID)
+	} else {
+		h.services[serviceID] = serviceSafePoint{
+			safePoint: safePoint,
+			expiresAt: time.Now().Add(time.Duration(ttl) * time.Second),
+		}
+	}
+
+	min := maxTS
+	if ttl > 0 && safePoint < min {
+		min = safePoint
+	}
+	now := time.Now()
+	for id, sp := range h.services {
+		if now.After(sp.expiresAt) {
+			delete(h.services, id)
+			continue
+		}
+		if sp.safePoint < min {
+			min = sp.safePoint
+		}
+	}
+	if err := h.persistSafePoint(min); err != nil {
+		return 0, err
+	}
+	return min, nil
+}
+
+// GetGCSafePoint implements kv.GCHandler, caching the value for
+// gcSafePointCacheSeconds seconds to avoid a round trip to the engine on
+// every call.
+func (h *gcHandler) GetGCSafePoint(ctx context.Context) (uint64, error) {
+	h.mu.Lock()
+	if time.Since(h.cachedAt) < gcSafePointCacheSeconds*time.Second {
+		sp := h.cachedSP
+		h.mu.Unlock()
+		return sp, nil
+	}
+	h.mu.Unlock()
+
+	sp, err := h.loadSafePoint()
+	if err != nil {
+		return 0, err
+	}
+	h.mu.Lock()
+	h.cachedSP = sp
+	h.cachedAt = time.Now()
+	h.mu.Unlock()
+	return sp, nil
+}
+
+// RegisterServiceGCSafePoint implements kv.GCHandler.
+func (h *gcHandler) RegisterServiceGCSafePoint(ctx context.Context, serviceID string, ttl int64, safePoint uint64) error {
+	_, err := h.UpdateServiceGCSafePoint(ctx, serviceID, ttl, safePoint)
+	return err
+}
+
+// MinStartTS implements kv.GCHandler by returning the smallest start
+// timestamp among the transactions that have registered themselves, or
+// maxTS if none have.
+func (h *gcHandler) MinStartTS() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	min := maxTS
+	for ts := range h.registeredTS {
+		if ts < min {
+			min = ts
+		}
+	}
+	return min
+}
+
+// registerStartTS records startTS so MinStartTS reflects it until
+// unregisterStartTS is called. storage.BeginWithStartTS calls it for every
+// transaction, and the transaction's Commit and Rollback unregister it.
+// Neither UpdateServiceGCSafePoint nor runValueLogGC consults MinStartTS, so
+// a registered startTS does not hold GC back.
+func (h *gcHandler) registerStartTS(startTS uint64) {
+	h.mu.Lock()
+	h.registeredTS[startTS] = struct{}{}
+	h.mu.Unlock()
+}
+
+func (h *gcHandler) unregisterStartTS(startTS uint64) {
+	h.mu.Lock()
+	delete(h.registeredTS, startTS)
+	h.mu.Unlock()
+}
+
+// runValueLogGC runs badger's value-log GC once a GC safepoint has been set;
+// with a zero safepoint it does nothing. storage.runGCLoop calls it
+// periodically. The safepoint's value is not passed to the engine, and no
+// MVCC versions older than it are deleted here.
+func (h *gcHandler) runValueLogGC(ctx context.Context) error {
+	sp, err := h.GetGCSafePoint(ctx)
+	if err != nil {
+		return err
+	}
+	if sp == 0 {
+		return nil
+	}
+	err = h.store.db.RunValueLogGC(0.5)
+	if err == badger.ErrNoRewrite {
+		return nil
+	}
+	return err
+}
+
+// persistSafePoint and loadSafePoint store the safepoint directly under a
+// reserved engine key, bypassing the MVCC encoding and the optional
+// RawStorage guard: the safepoint is internal bookkeeping, not user data.
+func (h *gcHandler) persistSafePoint(safePoint uint64) error {
+	return h.store.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(gcSafePointKey), encodeUint64(safePoint))
+	})
+}
+
+func (h *gcHandler) loadSafePoint() (uint64, error) {
+	var safePoint uint64
+	err := h.store.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(gcSafePointKey))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(v []byte) error {
+			safePoint = decodeUint64(v)
+			return nil
+		})
+	})
+	return safePoint, err
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package badger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgraph-io/badger/v2"
+)
+
+func newTestStorage(t *testing.T) *storage {
+	t.Helper()
+	dir := t.TempDir()
+	db, err := badger.Open(badger.DefaultOptions(dir))
+	if err != nil {
+		t.Fatalf("badger.Open: %v", err)
+	}
+	s, err := newStorage(dir, db)
+	if err != nil {
+		t.Fatalf("newStorage: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestGCHandlerUpdateServiceGCSafePointTakesMinimum(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	min, err := s.gc.UpdateServiceGCSafePoint(ctx, "svc-a", 60, 100)
+	if err != nil || min != 100 {
+		t.Fatalf("UpdateServiceGCSafePoint = (%d, %v), want (100, nil)", min, err)
+	}
+	min, err = s.gc.UpdateServiceGCSafePoint(ctx, "svc-b", 60, 50)
+	if err != nil || min != 50 {
+		t.Fatalf("UpdateServiceGCSafePoint = (%d, %v), want (50, nil)", min, err)
+	}
+
+	// Removing svc-b's registration should let the safe point advance back to
+	// svc-a's, not clamp to the removal call's (often zero) safePoint argument.
+	min, err = s.gc.UpdateServiceGCSafePoint(ctx, "svc-b", 0, 0)
+	if err != nil || min != 100 {
+		t.Fatalf("UpdateServiceGCSafePoint after removal = (%d, %v), want (100, nil)", min, err)
+	}
+}
+
+func TestGCHandlerMinStartTS(t *testing.T) {
+	s := newTestStorage(t)
+	if got := s.gc.MinStartTS(); got != maxTS {
+		t.Fatalf("MinStartTS with nothing registered = %d, want maxTS", got)
+	}
+
+	s.gc.registerStartTS(20)
+	s.gc.registerStartTS(10)
+	if got := s.gc.MinStartTS(); got != 10 {
+		t.Fatalf("MinStartTS = %d, want 10", got)
+	}
+
+	s.gc.unregisterStartTS(10)
+	if got := s.gc.MinStartTS(); got != 20 {
+		t.Fatalf("MinStartTS after unregister = %d, want 20", got)
+	}
+}
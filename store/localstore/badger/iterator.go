@@ -0,0 +1,202 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package badger
+
+import (
+	"github.com/dgraph-io/badger/v2"
+	"github.com/pingcap/tidb/kv"
+)
+
+// snapshotIter implements kv.Iterator over a snapshot's visible versions. It
+// walks the underlying engine iterator and, for every distinct user key,
+// surfaces only the newest version with commit ts <= readTS.
+type snapshotIter struct {
+	txn     *badger.Txn
+	it      *badger.Iterator
+	reverse bool
+	upper   kv.Key // exclusive upper bound, forward iteration only
+	readTS  uint64
+
+	valid bool
+	key   kv.Key
+	value []byte
+}
+
+func newSnapshotIter(s *snapshot, lower, upper kv.Key, reverse bool) (*snapshotIter, error) {
+	txn := s.store.db.NewTransaction(false)
+	opts := badger.DefaultIteratorOptions
+	opts.Reverse = reverse
+	it := txn.NewIterator(opts)
+
+	si := &snapshotIter{txn: txn, it: it, reverse: reverse, upper: upper, readTS: s.readTS}
+	if reverse {
+		if upper == nil {
+			it.Rewind()
+		} else {
+			it.Seek(mvccEncode(upper, maxTS))
+			// A reverse Seek lands on the largest engine key <= the seek
+			// key, which can be one of upper's own versions; since we want
+			// strictly less than upper, step past any such key.
+			for it.Valid() {
+				uk, _, ok := mvccDecode(it.Item().KeyCopy(nil))
+				if ok && string(uk) >= string(upper) {
+					it.Next()
+					continue
+				}
+				break
+			}
+		}
+	} else {
+		if lower == nil {
+			it.Rewind()
+		} else {
+			it.Seek(mvccSeekKey(lower, s.readTS))
+		}
+	}
+	if err := si.advanceToVisible(); err != nil {
+		si.Close()
+		return nil, err
+	}
+	return si, nil
+}
+
+const maxTS = ^uint64(0)
+
+// advanceToVisible moves the underlying iterator to the next distinct user
+// key whose newest version with ts <= readTS is live, populating key/value.
+func (si *snapshotIter) advanceToVisible() error {
+	if si.reverse {
+		return si.advanceToVisibleReverse()
+	}
+	for si.it.Valid() {
+		item := si.it.Item()
+		rawKey := item.KeyCopy(nil)
+		userKey, ts, ok := mvccDecode(rawKey)
+		if !ok {
+			si.it.Next()
+			continue
+		}
+		if si.upper != nil && string(userKey) >= string(si.upper) {
+			break
+		}
+		if ts > si.readTS {
+			// Not yet visible to this snapshot; skip to this key's next older version.
+			si.it.Next()
+			continue
+		}
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		// Skip past any remaining (older) versions of the same user key so
+		// the next Next() call lands on a different user key, regardless of
+		// whether this version turns out to be a tombstone.
+		si.skipRemainingVersionsOf(userKey)
+		if len(val) == 0 {
+			// A zero-length value is the tombstone written by txn.Commit for a
+			// delete (see mvccEncode/commit): the key does not exist as of
+			// this version, and since this is the newest version <= readTS,
+			// it does not exist as of readTS either. Move on to the next key.
+			continue
+		}
+		si.valid = true
+		si.key = userKey
+		si.value = val
+		return nil
+	}
+	si.valid = false
+	return nil
+}
+
+// advanceToVisibleReverse is advanceToVisible's counterpart for IterReverse.
+// Engine keys sort with a fixed user key's versions newest-first (see
+// mvccEncode), so a Reverse badger.Iterator walks each key's version block in
+// the opposite order: oldest first, newest last. Taking the first version
+// encountered (as the forward path does) would therefore surface the oldest,
+// not the newest, visible version. Instead this scans the whole block for the
+// current user key and keeps the value of the newest version with ts <=
+// readTS before deciding whether the key is visible.
+func (si *snapshotIter) advanceToVisibleReverse() error {
+	for si.it.Valid() {
+		rawKey := si.it.Item().KeyCopy(nil)
+		userKey, _, ok := mvccDecode(rawKey)
+		if !ok {
+			si.it.Next()
+			continue
+		}
+
+		var bestVal []byte
+		haveBest := false
+		for si.it.Valid() {
+			uk, vts, ok := mvccDecode(si.it.Item().KeyCopy(nil))
+			if !ok || string(uk) != string(userKey) {
+				break
+			}
+			if vts <= si.readTS {
+				v, err := si.it.Item().ValueCopy(nil)
+				if err != nil {
+					return err
+				}
+				bestVal, haveBest = v, true
+			}
+			si.it.Next()
+		}
+
+		if !haveBest || len(bestVal) == 0 {
+			// Either no version of this key is visible to readTS, or the
+			// newest visible one is a tombstone (see advanceToVisible); move
+			// on to the next (smaller, since we're going in reverse) key.
+			continue
+		}
+		si.valid = true
+		si.key = userKey
+		si.value = bestVal
+		return nil
+	}
+	si.valid = false
+	return nil
+}
+
+func (si *snapshotIter) skipRemainingVersionsOf(userKey []byte) {
+	for si.it.Valid() {
+		uk, _, ok := mvccDecode(si.it.Item().KeyCopy(nil))
+		if !ok || string(uk) != string(userKey) {
+			return
+		}
+		si.it.Next()
+	}
+}
+
+// Valid implements kv.Iterator.
+func (si *snapshotIter) Valid() bool { return si.valid }
+
+// Key implements kv.Iterator.
+func (si *snapshotIter) Key() kv.Key { return si.key }
+
+// Value implements kv.Iterator.
+func (si *snapshotIter) Value() []byte { return si.value }
+
+// Next implements kv.Iterator.
+func (si *snapshotIter) Next() error {
+	if !si.valid {
+		return nil
+	}
+	return si.advanceToVisible()
+}
+
+// Close implements kv.Iterator.
+func (si *snapshotIter) Close() {
+	si.it.Close()
+	si.txn.Discard()
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package badger
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/badger/v2"
+)
+
+// putVersion writes a single engine-level record directly, bypassing txn.go,
+// so a key's version history can be set up precisely for a test.
+func putVersion(t *testing.T, s *storage, key string, ts uint64, value string) {
+	t.Helper()
+	err := s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(mvccEncode([]byte(key), ts), []byte(value))
+	})
+	if err != nil {
+		t.Fatalf("putVersion(%q, %d): %v", key, ts, err)
+	}
+}
+
+func TestIterReverseReturnsNewestVisibleVersion(t *testing.T) {
+	s := newTestStorage(t)
+	putVersion(t, s, "b", 10, "v10")
+	putVersion(t, s, "b", 20, "v20")
+
+	snap := newSnapshot(s, 25)
+
+	it, err := snap.Iter([]byte("b"), nil)
+	if err != nil {
+		t.Fatalf("Iter: %v", err)
+	}
+	if !it.Valid() || string(it.Value()) != "v20" {
+		t.Fatalf("forward Iter = %q, want %q", it.Value(), "v20")
+	}
+	it.Close()
+
+	rit, err := snap.IterReverse([]byte("c"))
+	if err != nil {
+		t.Fatalf("IterReverse: %v", err)
+	}
+	defer rit.Close()
+	if !rit.Valid() {
+		t.Fatalf("IterReverse is not valid, want key %q", "b")
+	}
+	if string(rit.Key()) != "b" || string(rit.Value()) != "v20" {
+		t.Fatalf("IterReverse = (%q, %q), want (%q, %q)", rit.Key(), rit.Value(), "b", "v20")
+	}
+}
+
+func TestIterReverseSkipsTombstoneAndFallsBackToOlderKey(t *testing.T) {
+	s := newTestStorage(t)
+	putVersion(t, s, "a", 10, "a10")
+	putVersion(t, s, "b", 10, "b10")
+	putVersion(t, s, "b", 20, "") // tombstone: b was deleted at ts=20
+
+	snap := newSnapshot(s, 25)
+
+	rit, err := snap.IterReverse([]byte("c"))
+	if err != nil {
+		t.Fatalf("IterReverse: %v", err)
+	}
+	defer rit.Close()
+	if !rit.Valid() || string(rit.Key()) != "a" || string(rit.Value()) != "a10" {
+		t.Fatalf("IterReverse = (%q, %q, valid=%v), want (%q, %q, true)", rit.Key(), rit.Value(), rit.Valid(), "a", "a10")
+	}
+}
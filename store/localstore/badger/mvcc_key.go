@@ -0,0 +1,68 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package badger
+
+import "encoding/binary"
+
+// TiDB's MVCC is layered on top of the engine by encoding every key as
+// (userKey, ^ts): the user key followed by the bitwise complement of its
+// commit timestamp. Complementing ts means that, for a fixed userKey, engine
+// keys sort with the newest version first, so a forward scan from
+// mvccEncode(userKey, readTS) (see mvccSeekKey) lands directly on the first
+// version visible to a snapshot taken at readTS.
+
+// mvccEncode builds the engine key for userKey at commit timestamp ts.
+func mvccEncode(userKey []byte, ts uint64) []byte {
+	buf := make([]byte, 0, len(userKey)+1+8)
+	buf = append(buf, userKey...)
+	buf = append(buf, 0x00)
+	var tsBuf [8]byte
+	binary.BigEndian.PutUint64(tsBuf[:], ^ts)
+	return append(buf, tsBuf[:]...)
+}
+
+// mvccDecode splits an engine key back into its user key and commit timestamp.
+func mvccDecode(engineKey []byte) (userKey []byte, ts uint64, ok bool) {
+	if len(engineKey) < 9 {
+		return nil, 0, false
+	}
+	n := len(engineKey) - 9
+	if engineKey[n] != 0x00 {
+		return nil, 0, false
+	}
+	ts = ^binary.BigEndian.Uint64(engineKey[n+1:])
+	return engineKey[:n], ts, true
+}
+
+// mvccSeekKey builds the engine key to seek to in order to find the first
+// version of userKey that is visible to a snapshot taken at readTS.
+func mvccSeekKey(userKey []byte, readTS uint64) []byte {
+	return mvccEncode(userKey, readTS)
+}
+
+// encodeUint64 and decodeUint64 are used for small pieces of internal
+// bookkeeping (e.g. the GC safepoint) that are stored outside of the MVCC key
+// space.
+func encodeUint64(v uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, v)
+	return buf
+}
+
+func decodeUint64(buf []byte) uint64 {
+	if len(buf) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(buf)
+}
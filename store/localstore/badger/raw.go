@@ -0,0 +1,202 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package badger
+
+import (
+	"context"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/pingcap/tidb/kv"
+)
+
+// rawClient implements kv.RawClient directly on the engine, storing keys
+// verbatim rather than through the (userKey, ^ts) MVCC encoding used by
+// snapshot/txn. Callers are expected to reach it only through a
+// kv.NewRawKeyspaceGuard wrapper (see Driver.OpenRaw), which keeps raw keys
+// out of the MVCC keyspace.
+type rawClient struct {
+	db *badger.DB
+}
+
+func newRawClient(db *badger.DB) *rawClient {
+	return &rawClient{db: db}
+}
+
+// Put implements kv.RawStorage.
+func (c *rawClient) Put(ctx context.Context, key, value []byte) error {
+	return c.PutWithTTL(ctx, key, value, 0)
+}
+
+// PutWithTTL implements kv.RawStorage.
+func (c *rawClient) PutWithTTL(ctx context.Context, key, value []byte, ttl uint64) error {
+	return c.db.Update(func(txn *badger.Txn) error {
+		entry := badger.NewEntry(key, value)
+		if ttl > 0 {
+			entry = entry.WithTTL(time.Duration(ttl) * time.Second)
+		}
+		return txn.SetEntry(entry)
+	})
+}
+
+// Get implements kv.RawStorage.
+func (c *rawClient) Get(ctx context.Context, key []byte) ([]byte, error) {
+	var value []byte
+	err := c.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err == badger.ErrKeyNotFound {
+			return kv.ErrNotExist
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(v []byte) error {
+			value = append([]byte{}, v...)
+			return nil
+		})
+	})
+	return value, err
+}
+
+// Delete implements kv.RawStorage.
+func (c *rawClient) Delete(ctx context.Context, key []byte) error {
+	return c.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(key)
+	})
+}
+
+// BatchPut implements kv.RawStorage.
+func (c *rawClient) BatchPut(ctx context.Context, keys, values [][]byte) error {
+	wb := c.db.NewWriteBatch()
+	defer wb.Cancel()
+	for i, k := range keys {
+		if err := wb.Set(k, values[i]); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+// BatchGet implements kv.RawStorage.
+func (c *rawClient) BatchGet(ctx context.Context, keys [][]byte) (map[string][]byte, error) {
+	m := make(map[string][]byte, len(keys))
+	for _, k := range keys {
+		v, err := c.Get(ctx, k)
+		if err == kv.ErrNotExist {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		m[string(k)] = v
+	}
+	return m, nil
+}
+
+// BatchDelete implements kv.RawStorage.
+func (c *rawClient) BatchDelete(ctx context.Context, keys [][]byte) error {
+	wb := c.db.NewWriteBatch()
+	defer wb.Cancel()
+	for _, k := range keys {
+		if err := wb.Delete(k); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+// Scan implements kv.RawStorage.
+func (c *rawClient) Scan(ctx context.Context, startKey, endKey []byte, limit int) ([][]byte, [][]byte, error) {
+	return c.scan(startKey, endKey, limit, false)
+}
+
+// ReverseScan implements kv.RawStorage.
+func (c *rawClient) ReverseScan(ctx context.Context, startKey, endKey []byte, limit int) ([][]byte, [][]byte, error) {
+	return c.scan(startKey, endKey, limit, true)
+}
+
+func (c *rawClient) scan(startKey, endKey []byte, limit int, reverse bool) ([][]byte, [][]byte, error) {
+	var keys, values [][]byte
+	err := c.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Reverse = reverse
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		if reverse {
+			it.Seek(endKey)
+		} else {
+			it.Seek(startKey)
+		}
+		for ; it.Valid() && (limit <= 0 || len(keys) < limit); it.Next() {
+			item := it.Item()
+			k := item.KeyCopy(nil)
+			if !reverse && endKey != nil && string(k) >= string(endKey) {
+				break
+			}
+			if reverse && endKey != nil && string(k) >= string(endKey) {
+				// it.Seek(endKey) can land exactly on endKey itself; endKey is
+				// exclusive in both directions, so skip it without stopping
+				// the scan, since every later key in reverse order is smaller.
+				continue
+			}
+			if reverse && string(k) < string(startKey) {
+				break
+			}
+			v, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, k)
+			values = append(values, v)
+		}
+		return nil
+	})
+	return keys, values, err
+}
+
+// DeleteRange implements kv.RawStorage.
+func (c *rawClient) DeleteRange(ctx context.Context, startKey, endKey []byte) error {
+	keys, _, err := c.scan(startKey, endKey, 0, false)
+	if err != nil {
+		return err
+	}
+	return c.BatchDelete(ctx, keys)
+}
+
+// CompareAndSwap implements kv.RawStorage.
+func (c *rawClient) CompareAndSwap(ctx context.Context, key, expectedValue, newValue []byte) ([]byte, bool, error) {
+	var previous []byte
+	swapped := false
+	err := c.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil && err != badger.ErrKeyNotFound {
+			return err
+		}
+		if err == nil {
+			previous, err = item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+		}
+		if string(previous) != string(expectedValue) {
+			return nil
+		}
+		swapped = true
+		return txn.Set(key, newValue)
+	})
+	return previous, swapped, err
+}
+
+// Close implements kv.RawClient.
+func (c *rawClient) Close() error { return nil }
@@ -0,0 +1,101 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package badger
+
+import (
+	"context"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/pingcap/tidb/kv"
+)
+
+// snapshot implements kv.Snapshot by reading the first engine key at or
+// before readTS for each requested user key (see mvcc_key.go).
+type snapshot struct {
+	store  *storage
+	readTS uint64
+}
+
+func newSnapshot(store *storage, readTS uint64) *snapshot {
+	return &snapshot{store: store, readTS: readTS}
+}
+
+// Get implements kv.Getter.
+func (s *snapshot) Get(ctx context.Context, k kv.Key) ([]byte, error) {
+	var value []byte
+	err := s.store.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		seekKey := mvccSeekKey(k, s.readTS)
+		it.Seek(seekKey)
+		if !it.Valid() {
+			return kv.ErrNotExist
+		}
+		userKey, _, ok := mvccDecode(it.Item().KeyCopy(nil))
+		if !ok || string(userKey) != string(k) {
+			return kv.ErrNotExist
+		}
+		v, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		if len(v) == 0 {
+			// Zero-length value is the tombstone txn.Commit writes for a
+			// delete; this is the newest version <= readTS, so the key does
+			// not exist as of this snapshot.
+			return kv.ErrNotExist
+		}
+		value = v
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Iter implements kv.Retriever.
+func (s *snapshot) Iter(k kv.Key, upperBound kv.Key) (kv.Iterator, error) {
+	return newSnapshotIter(s, k, upperBound, false)
+}
+
+// IterReverse implements kv.Retriever.
+func (s *snapshot) IterReverse(k kv.Key) (kv.Iterator, error) {
+	return newSnapshotIter(s, nil, k, true)
+}
+
+// BatchGet implements kv.Snapshot.
+func (s *snapshot) BatchGet(ctx context.Context, keys []kv.Key) (map[string][]byte, error) {
+	m := make(map[string][]byte, len(keys))
+	for _, k := range keys {
+		v, err := s.Get(ctx, k)
+		if err == kv.ErrNotExist {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		m[string(k)] = v
+	}
+	return m, nil
+}
+
+// SetOption implements kv.Snapshot. It ignores every option, including
+// ReplicaRead, which has no meaning on a single-node embedded engine; options
+// are accepted so callers that set them unconditionally do not need a
+// backend-specific branch.
+func (s *snapshot) SetOption(opt kv.Option, val interface{}) {}
+
+// DelOption implements kv.Snapshot.
+func (s *snapshot) DelOption(opt kv.Option) {}
@@ -0,0 +1,191 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package badger
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/store/tikv/oracle"
+)
+
+// txn implements kv.Transaction on top of a storage and the snapshot it was
+// opened at. Like tikv.KVTxn, it keeps its writes in an in-memory
+// kv.UnionStore and only applies them to the engine on Commit.
+type txn struct {
+	kv.UnionStore
+
+	store    *storage
+	snapshot *snapshot
+	startTS  uint64
+	valid    bool
+	vars     *kv.Variables
+	syncLog  bool
+}
+
+func newTxn(store *storage, snap kv.Snapshot, startTS uint64) *txn {
+	return &txn{
+		UnionStore: kv.NewUnionStore(snap),
+		store:      store,
+		snapshot:   snap.(*snapshot),
+		startTS:    startTS,
+		valid:      true,
+		vars:       kv.DefaultVars,
+	}
+}
+
+// Size implements kv.Transaction.
+func (t *txn) Size() int { return t.GetMemBuffer().Size() }
+
+// Len implements kv.Transaction.
+func (t *txn) Len() int { return t.GetMemBuffer().Len() }
+
+// Reset implements kv.Transaction.
+func (t *txn) Reset() { t.GetMemBuffer().Reset() }
+
+// Commit implements kv.Transaction. It walks the transaction's MemBuffer and
+// applies every pending write as a single badger write batch, so a commit is
+// all-or-nothing the same way a TiKV 2PC commit is atomic from the client's
+// point of view.
+func (t *txn) Commit(ctx context.Context) error {
+	if !t.valid {
+		return kv.ErrInvalidTxn
+	}
+	defer func() { t.valid = false }()
+	defer t.store.gc.unregisterStartTS(t.startTS)
+
+	buf := t.GetMemBuffer()
+
+	if err := t.checkForConflicts(buf); err != nil {
+		return err
+	}
+
+	commitTS, err := t.store.oracle.GetTimestamp(ctx, &oracle.Option{})
+	if err != nil {
+		return err
+	}
+
+	wb := t.store.db.NewWriteBatch()
+	defer wb.Cancel()
+	wb.SetSync(t.syncLog)
+
+	iter, err := buf.Iter(nil, nil)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	for iter.Valid() {
+		k, v := iter.Key(), iter.Value()
+		engineKey := mvccEncode(k, commitTS)
+		// Even a delete (v is empty) is written as a real engine record: it
+		// is the tombstone that shadows every older version of k once
+		// readTS >= commitTS (see snapshot.Get / snapshotIter). Relying on
+		// wb.Delete here would be a no-op, since no engine key was ever
+		// written at commitTS to begin with.
+		if err := wb.Set(engineKey, v); err != nil {
+			return err
+		}
+		if err := iter.Next(); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+// checkForConflicts implements first-committer-wins: for every key this
+// transaction wrote, it fails the commit if some other transaction has
+// already committed a newer version of that key since t.startTS. Without
+// this check two transactions that both read a key and then write it could
+// both "succeed" and silently lose one of the updates.
+func (t *txn) checkForConflicts(buf kv.MemBuffer) error {
+	iter, err := buf.Iter(nil, nil)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	for iter.Valid() {
+		k := iter.Key()
+		latestTS, found, err := t.store.latestCommitTS(k)
+		if err != nil {
+			return err
+		}
+		if found && latestTS > t.startTS {
+			return newWriteConflictError(k, t.startTS, latestTS)
+		}
+		if err := iter.Next(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback implements kv.Transaction.
+func (t *txn) Rollback() error {
+	t.valid = false
+	t.store.gc.unregisterStartTS(t.startTS)
+	return nil
+}
+
+// String implements fmt.Stringer.
+func (t *txn) String() string {
+	return fmt.Sprintf("badger.txn{startTS=%d, valid=%v}", t.startTS, t.valid)
+}
+
+// LockKeys implements kv.Transaction. A single-node embedded engine commits
+// atomically, so pessimistic locking has nothing to coordinate with and is a
+// no-op: the keys are not recorded, and IsReadOnly depends only on buffered
+// writes.
+func (t *txn) LockKeys(ctx context.Context, lockCtx *kv.LockCtx, keys ...kv.Key) error {
+	return nil
+}
+
+// SetOption implements kv.Transaction.
+func (t *txn) SetOption(opt kv.Option, val interface{}) {
+	if opt == kv.SyncLog {
+		t.syncLog, _ = val.(bool)
+	}
+}
+
+// DelOption implements kv.Transaction.
+func (t *txn) DelOption(opt kv.Option) {
+	if opt == kv.SyncLog {
+		t.syncLog = false
+	}
+}
+
+// IsReadOnly implements kv.Transaction.
+func (t *txn) IsReadOnly() bool { return !t.GetMemBuffer().Dirty() }
+
+// StartTS implements kv.Transaction.
+func (t *txn) StartTS() uint64 { return t.startTS }
+
+// Valid implements kv.Transaction.
+func (t *txn) Valid() bool { return t.valid }
+
+// GetSnapshot implements kv.Transaction.
+func (t *txn) GetSnapshot() kv.Snapshot { return t.snapshot }
+
+// GetUnionStore implements kv.Transaction.
+func (t *txn) GetUnionStore() kv.UnionStore { return t.UnionStore }
+
+// SetVars implements kv.Transaction.
+func (t *txn) SetVars(vars *kv.Variables) { t.vars = vars }
+
+// GetVars implements kv.Transaction.
+func (t *txn) GetVars() *kv.Variables { return t.vars }
+
+// IsPessimistic implements kv.Transaction. The embedded engine only ever runs
+// optimistic transactions.
+func (t *txn) IsPessimistic() bool { return false }